@@ -0,0 +1,33 @@
+// Package compute wraps the subset of the GCP compute API the actuator
+// needs, so callers can depend on an interface instead of a concrete
+// *compute.Service, and tests can substitute NewComputeServiceMock.
+package compute
+
+import (
+	compute "google.golang.org/api/compute/v1"
+)
+
+// GCPComputeService is the subset of the compute API the actuator's image
+// resolution and validation logic needs.
+type GCPComputeService interface {
+	ImagesGet(project, image string) (*compute.Image, error)
+	ImagesGetFromFamily(project, family string) (*compute.Image, error)
+}
+
+// computeService is the real GCPComputeService, backed by a *compute.Service.
+type computeService struct {
+	service *compute.Service
+}
+
+// NewComputeService returns a GCPComputeService backed by service.
+func NewComputeService(service *compute.Service) GCPComputeService {
+	return &computeService{service: service}
+}
+
+func (c *computeService) ImagesGet(project, image string) (*compute.Image, error) {
+	return c.service.Images.Get(project, image).Do()
+}
+
+func (c *computeService) ImagesGetFromFamily(project, family string) (*compute.Image, error) {
+	return c.service.Images.GetFromFamily(project, family).Do()
+}
@@ -0,0 +1,104 @@
+package compute
+
+import (
+	"fmt"
+
+	compute "google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// uefiCompatibleFeature mirrors the GuestOsFeatures.Type value GCP sets on
+// images that support booting with UEFI firmware.
+const uefiCompatibleFeature = "UEFI_COMPATIBLE"
+
+// errNotFoundProject is a project name that always reports every image and
+// family as not found, regardless of the name requested. Tests use this to
+// exercise the not-found path without needing a dedicated fixture per
+// scenario.
+const errNotFoundProject = "errImageNotFound"
+
+func uefiImage(name string) *compute.Image {
+	return &compute.Image{
+		Name:            name,
+		GuestOsFeatures: []*compute.GuestOsFeatures{{Type: uefiCompatibleFeature}},
+	}
+}
+
+func nonUEFIImage(name string) *compute.Image {
+	return &compute.Image{Name: name}
+}
+
+// MockComputeService is an in-memory GCPComputeService backed by a
+// hand-maintained set of fixtures, used by the util package's tests.
+type MockComputeService struct {
+	images        map[string]map[string]*compute.Image
+	imageFamilies map[string]map[string]*compute.Image
+}
+
+// NewComputeServiceMock returns a MockComputeService pre-populated with the
+// fixtures exercised by this repository's tests, along with it as a
+// GCPComputeService.
+func NewComputeServiceMock() (*MockComputeService, GCPComputeService) {
+	mock := &MockComputeService{
+		images: map[string]map[string]*compute.Image{
+			"fooproject": {
+				"uefi-image":       uefiImage("uefi-image"),
+				"fooimage":         nonUEFIImage("fooimage"),
+				"arm64-uefi-image": {Name: "arm64-uefi-image", Architecture: "ARM64", GuestOsFeatures: []*compute.GuestOsFeatures{{Type: uefiCompatibleFeature}}},
+				"arm64-image":      {Name: "arm64-image", Architecture: "ARM64"},
+				"cmek-encrypted-image": {
+					Name:            "cmek-encrypted-image",
+					GuestOsFeatures: []*compute.GuestOsFeatures{{Type: uefiCompatibleFeature}},
+					ImageEncryptionKey: &compute.CustomerEncryptionKey{
+						KmsKeyName: "projects/fooproject/locations/global/keyRings/my-ring/cryptoKeys/image-key",
+					},
+				},
+			},
+			"simple-project": {
+				"uefi-image": uefiImage("uefi-image"),
+				"non-uefi":   nonUEFIImage("non-uefi"),
+			},
+		},
+		imageFamilies: map[string]map[string]*compute.Image{
+			"fooproject": {
+				"uefi-image-family": uefiImage("uefi-image-family"),
+				"fooimage":          nonUEFIImage("fooimage"),
+			},
+			"debian-cloud":      {"debian-11": uefiImage("debian-11")},
+			"ubuntu-os-cloud":   {"ubuntu-2204-lts": uefiImage("ubuntu-2204-lts")},
+			"centos-cloud":      {"centos-stream-9": uefiImage("centos-stream-9")},
+			"rhel-cloud":        {"rhel-9": uefiImage("rhel-9")},
+			"suse-cloud":        {"sles-15": uefiImage("sles-15")},
+			"windows-cloud":     {"windows-2022": uefiImage("windows-2022")},
+			"windows-sql-cloud": {"sql-2019-standard-windows-2022-dc": uefiImage("sql-2019-standard-windows-2022-dc")},
+		},
+	}
+
+	return mock, mock
+}
+
+func (m *MockComputeService) ImagesGet(project, image string) (*compute.Image, error) {
+	if project == errNotFoundProject {
+		return nil, &googleapi.Error{Code: 404, Message: fmt.Sprintf("image %q not found in project %q", image, project)}
+	}
+
+	found, ok := m.images[project][image]
+	if !ok {
+		return nil, &googleapi.Error{Code: 404, Message: fmt.Sprintf("image %q not found in project %q", image, project)}
+	}
+
+	return found, nil
+}
+
+func (m *MockComputeService) ImagesGetFromFamily(project, family string) (*compute.Image, error) {
+	if project == errNotFoundProject {
+		return nil, &googleapi.Error{Code: 404, Message: fmt.Sprintf("image family %q not found in project %q", family, project)}
+	}
+
+	found, ok := m.imageFamilies[project][family]
+	if !ok {
+		return nil, &googleapi.Error{Code: 404, Message: fmt.Sprintf("image family %q not found in project %q", family, project)}
+	}
+
+	return found, nil
+}
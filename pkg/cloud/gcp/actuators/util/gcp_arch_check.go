@@ -0,0 +1,88 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/compute/v1"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	computeservice "github.com/openshift/machine-api-provider-gcp/pkg/cloud/gcp/actuators/services/compute"
+)
+
+var (
+	// ErrArchMismatch is returned when the boot image's architecture does
+	// not match the architecture implied by the machine type family.
+	ErrArchMismatch = errors.New("image architecture does not match machine type architecture")
+	// ErrArm64RequiresUEFI is returned when an arm64 machine type is paired
+	// with an image that does not support UEFI, which arm64 shapes require
+	// in order to boot at all.
+	ErrArm64RequiresUEFI = errors.New("arm64 machine types require a UEFI-compatible image")
+)
+
+// arm64MachineTypePrefixes lists the machine type family prefixes known to
+// run on Arm64 hosts, such as the Ampere Altra based T2A family.
+var arm64MachineTypePrefixes = []string{"t2a-"}
+
+// archX8664 and archARM64 mirror the compute.Image.Architecture values GCP
+// reports ("X86_64", "ARM64"). Images created before architecture was
+// tracked report an empty string, which we treat as X86_64.
+const (
+	archX8664 = "X86_64"
+	archARM64 = "ARM64"
+)
+
+// machineTypeArchitecture returns the CPU architecture required by the given
+// machine type family, e.g. "t2a-standard-4" requires ARM64 while "n2-
+// standard-4", "e2-medium" and "c3-standard-4" require X86_64.
+func machineTypeArchitecture(machineType string) string {
+	for _, prefix := range arm64MachineTypePrefixes {
+		if strings.HasPrefix(machineType, prefix) {
+			return archARM64
+		}
+	}
+
+	return archX8664
+}
+
+// IsArchitectureCompatible reports whether the boot disk's image
+// architecture matches the architecture required by
+// providerSpec.MachineType, and that arm64 machine types are only paired
+// with UEFI-compatible images. Pass WithImageCache to resolve the image
+// through an ImageMetadataCache instead of the compute API directly.
+func IsArchitectureCompatible(computeService computeservice.GCPComputeService, providerSpec *machinev1.GCPMachineProviderSpec, opts ...ResolveImageOption) (bool, error) {
+	bootDisk, err := findBootDisk(providerSpec)
+	if err != nil {
+		return false, err
+	}
+
+	image, err := ResolveImage(computeService, providerSpec, bootDisk.Image, opts...)
+	if err != nil {
+		return false, err
+	}
+
+	return checkImageArchitecture(image, providerSpec)
+}
+
+// checkImageArchitecture applies the architecture compatibility rules to an
+// already-resolved image, so callers that already hold the image (such as
+// IsUEFICompatible) don't need to resolve it a second time.
+func checkImageArchitecture(image *compute.Image, providerSpec *machinev1.GCPMachineProviderSpec) (bool, error) {
+	wantArch := machineTypeArchitecture(providerSpec.MachineType)
+
+	imageArch := image.Architecture
+	if imageArch == "" {
+		imageArch = archX8664
+	}
+
+	if imageArch != wantArch {
+		return false, fmt.Errorf("%w: machine type %q requires %s images, but image %q is %s", ErrArchMismatch, providerSpec.MachineType, wantArch, image.Name, imageArch)
+	}
+
+	if wantArch == archARM64 && !imageHasUEFISupport(image) {
+		return false, fmt.Errorf("%w: machine type %q is arm64 but image %q does not support UEFI", ErrArm64RequiresUEFI, providerSpec.MachineType, image.Name)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,85 @@
+package util_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	computeservice "github.com/openshift/machine-api-provider-gcp/pkg/cloud/gcp/actuators/services/compute"
+	"github.com/openshift/machine-api-provider-gcp/pkg/cloud/gcp/actuators/util"
+)
+
+var _ = Describe("IsArchitectureCompatible", func() {
+	var (
+		computeService computeservice.GCPComputeService
+		providerSpec   *machinev1.GCPMachineProviderSpec
+
+		compatible bool
+		err        error
+	)
+
+	BeforeEach(func() {
+		_, computeService = computeservice.NewComputeServiceMock()
+		providerSpec = &machinev1.GCPMachineProviderSpec{
+			ProjectID: "fooproject",
+		}
+	})
+
+	type archInput struct {
+		image                string
+		machineType          string
+		expectedErrSubstring string
+		compatible           bool
+	}
+
+	var tableFunc func(in archInput) = func(in archInput) {
+		providerSpec.MachineType = in.machineType
+		providerSpec.Disks = []*machinev1.GCPDisk{
+			{
+				Boot:  true,
+				Image: in.image,
+			},
+		}
+
+		compatible, err = util.IsArchitectureCompatible(computeService, providerSpec)
+		if in.expectedErrSubstring != "" {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(in.expectedErrSubstring))
+		} else {
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(compatible).To(Equal(in.compatible))
+	}
+
+	DescribeTable("Machine type and image architecture pairings",
+		tableFunc,
+		Entry("arm64 image on a t2a machine type", archInput{
+			image:       "projects/fooproject/global/images/arm64-uefi-image",
+			machineType: "t2a-standard-4",
+			compatible:  true,
+		}),
+		Entry("x86 image on an n2 machine type", archInput{
+			image:       "projects/fooproject/global/images/uefi-image",
+			machineType: "n2-standard-4",
+			compatible:  true,
+		}),
+		Entry("arm64 image on an n2 machine type", archInput{
+			image:                "projects/fooproject/global/images/arm64-uefi-image",
+			machineType:          "n2-standard-4",
+			expectedErrSubstring: "image architecture does not match machine type architecture",
+			compatible:           false,
+		}),
+		Entry("x86 image on a t2a machine type", archInput{
+			image:                "projects/fooproject/global/images/uefi-image",
+			machineType:          "t2a-standard-4",
+			expectedErrSubstring: "image architecture does not match machine type architecture",
+			compatible:           false,
+		}),
+		Entry("arm64 image without UEFI support on a t2a machine type", archInput{
+			image:                "projects/fooproject/global/images/arm64-image",
+			machineType:          "t2a-standard-4",
+			expectedErrSubstring: "require a UEFI-compatible image",
+			compatible:           false,
+		}),
+	)
+})
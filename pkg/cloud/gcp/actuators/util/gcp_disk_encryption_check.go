@@ -0,0 +1,144 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	cloudkms "google.golang.org/api/cloudkms/v1"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	computeservice "github.com/openshift/machine-api-provider-gcp/pkg/cloud/gcp/actuators/services/compute"
+)
+
+// ErrBootDiskKeyMismatch is returned when the boot image is itself
+// CMEK-encrypted but the boot disk does not reference a key in the same key
+// ring, which would make the instance un-bootable.
+var ErrBootDiskKeyMismatch = errors.New("boot disk encryption key does not match the boot image encryption key")
+
+// kmsKeyNamePattern matches a fully qualified Cloud KMS crypto key name,
+// e.g. projects/my-project/locations/global/keyRings/my-ring/cryptoKeys/my-key.
+var kmsKeyNamePattern = regexp.MustCompile(`^projects/[^/]+/locations/[^/]+/keyRings/[^/]+/cryptoKeys/[^/]+$`)
+
+// kmsKeyRing returns the "projects/.../keyRings/..." prefix of a fully
+// qualified crypto key name, dropping the specific key, so two keys can be
+// compared for membership in the same ring.
+func kmsKeyRing(keyName string) string {
+	idx := strings.LastIndex(keyName, "/cryptoKeys/")
+	if idx == -1 {
+		return keyName
+	}
+
+	return keyName[:idx]
+}
+
+// KMSService is the subset of the Cloud KMS API ValidateDiskEncryption needs
+// in order to confirm a customer-managed key reference actually exists and
+// is enabled.
+type KMSService interface {
+	GetCryptoKey(keyName string) (*cloudkms.CryptoKey, error)
+}
+
+// DiskEncryptionOption customizes ValidateDiskEncryption.
+type DiskEncryptionOption func(*diskEncryptionOptions)
+
+type diskEncryptionOptions struct {
+	kmsService          KMSService
+	resolveImageOptions []ResolveImageOption
+}
+
+// WithKMSService makes ValidateDiskEncryption confirm each referenced key
+// exists and is enabled, via kmsService. Without this option, only the key
+// name format is validated.
+func WithKMSService(kmsService KMSService) DiskEncryptionOption {
+	return func(o *diskEncryptionOptions) {
+		o.kmsService = kmsService
+	}
+}
+
+// WithResolveImageOptions passes opts through to the ResolveImage call
+// ValidateDiskEncryption makes to inspect the boot image's own encryption
+// key, e.g. WithResolveImageOptions(WithImageCache(cache)) to share the same
+// ImageMetadataCache IsUEFICompatible uses instead of issuing a redundant
+// compute API call for the same image.
+func WithResolveImageOptions(opts ...ResolveImageOption) DiskEncryptionOption {
+	return func(o *diskEncryptionOptions) {
+		o.resolveImageOptions = opts
+	}
+}
+
+// ValidateDiskEncryption pre-validates the customer-managed encryption key
+// references on providerSpec.Disks, so a malformed, missing or disabled key
+// is caught during Machine reconcile rather than surfacing as an
+// instance-insert failure.
+func ValidateDiskEncryption(computeService computeservice.GCPComputeService, providerSpec *machinev1.GCPMachineProviderSpec, opts ...DiskEncryptionOption) error {
+	options := &diskEncryptionOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	for _, disk := range providerSpec.Disks {
+		if disk.EncryptionKey == nil || disk.EncryptionKey.KMSKeyName == "" {
+			continue
+		}
+
+		keyName := disk.EncryptionKey.KMSKeyName
+
+		if !kmsKeyNamePattern.MatchString(keyName) {
+			return fmt.Errorf("malformed KMS key reference %q: expected projects/*/locations/*/keyRings/*/cryptoKeys/*", keyName)
+		}
+
+		if options.kmsService != nil {
+			key, err := options.kmsService.GetCryptoKey(keyName)
+			if err != nil {
+				return fmt.Errorf("unable to retrieve KMS key %q: %v", keyName, err)
+			}
+
+			// Enablement is a property of the key's primary version, not of
+			// the CryptoKey resource itself.
+			if key.Primary == nil || key.Primary.State != "ENABLED" {
+				state := "unknown"
+				if key.Primary != nil {
+					state = key.Primary.State
+				}
+				return fmt.Errorf("KMS key %q is not enabled (primary version state %q)", keyName, state)
+			}
+		}
+	}
+
+	// The boot image's own encryption key must be checked regardless of
+	// whether the boot disk specifies one at all: a CMEK-encrypted image
+	// paired with a boot disk that has no key is exactly the mismatch this
+	// function exists to catch.
+	bootDisk, err := findBootDisk(providerSpec)
+	if err != nil {
+		return err
+	}
+
+	image, err := ResolveImage(computeService, providerSpec, bootDisk.Image, options.resolveImageOptions...)
+	if err != nil {
+		return err
+	}
+
+	if image.ImageEncryptionKey == nil || image.ImageEncryptionKey.KmsKeyName == "" {
+		return nil
+	}
+
+	bootImageKeyName := image.ImageEncryptionKey.KmsKeyName
+
+	var bootDiskKeyName string
+	if bootDisk.EncryptionKey != nil {
+		bootDiskKeyName = bootDisk.EncryptionKey.KMSKeyName
+	}
+
+	if bootDiskKeyName == "" {
+		return fmt.Errorf("%w: boot image is encrypted with %q but the boot disk specifies no encryption key", ErrBootDiskKeyMismatch, bootImageKeyName)
+	}
+
+	if kmsKeyRing(bootImageKeyName) != kmsKeyRing(bootDiskKeyName) {
+		return fmt.Errorf("%w: boot image key %q is not in the same key ring as boot disk key %q", ErrBootDiskKeyMismatch, bootImageKeyName, bootDiskKeyName)
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package util_test
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	cloudkms "google.golang.org/api/cloudkms/v1"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	computeservice "github.com/openshift/machine-api-provider-gcp/pkg/cloud/gcp/actuators/services/compute"
+	"github.com/openshift/machine-api-provider-gcp/pkg/cloud/gcp/actuators/util"
+)
+
+// fakeKMSService is a minimal in-memory util.KMSService used to exercise
+// ValidateDiskEncryption without depending on a real Cloud KMS client.
+type fakeKMSService struct {
+	keys map[string]*cloudkms.CryptoKey
+}
+
+func (f *fakeKMSService) GetCryptoKey(keyName string) (*cloudkms.CryptoKey, error) {
+	key, ok := f.keys[keyName]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found", keyName)
+	}
+
+	return key, nil
+}
+
+var _ = Describe("ValidateDiskEncryption", func() {
+	var (
+		computeService computeservice.GCPComputeService
+		providerSpec   *machinev1.GCPMachineProviderSpec
+		kmsService     *fakeKMSService
+
+		err error
+	)
+
+	const (
+		enabledKey  = "projects/fooproject/locations/global/keyRings/my-ring/cryptoKeys/my-key"
+		disabledKey = "projects/fooproject/locations/global/keyRings/my-ring/cryptoKeys/disabled-key"
+		otherRing   = "projects/fooproject/locations/global/keyRings/other-ring/cryptoKeys/my-key"
+	)
+
+	BeforeEach(func() {
+		_, computeService = computeservice.NewComputeServiceMock()
+		providerSpec = &machinev1.GCPMachineProviderSpec{
+			ProjectID: "fooproject",
+		}
+		kmsService = &fakeKMSService{
+			keys: map[string]*cloudkms.CryptoKey{
+				enabledKey:  {Name: enabledKey, Primary: &cloudkms.CryptoKeyVersion{State: "ENABLED"}},
+				disabledKey: {Name: disabledKey, Primary: &cloudkms.CryptoKeyVersion{State: "DISABLED"}},
+				otherRing:   {Name: otherRing, Primary: &cloudkms.CryptoKeyVersion{State: "ENABLED"}},
+			},
+		}
+	})
+
+	type encryptionInput struct {
+		keyName              string
+		expectedErrSubstring string
+	}
+
+	var tableFunc func(in encryptionInput) = func(in encryptionInput) {
+		providerSpec.Disks = []*machinev1.GCPDisk{
+			{
+				Boot:  true,
+				Image: "projects/fooproject/global/images/uefi-image",
+				EncryptionKey: &machinev1.GCPEncryptionKeyReference{
+					KMSKeyName: in.keyName,
+				},
+			},
+		}
+
+		err = util.ValidateDiskEncryption(computeService, providerSpec, util.WithKMSService(kmsService))
+		if in.expectedErrSubstring != "" {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(in.expectedErrSubstring))
+		} else {
+			Expect(err).ToNot(HaveOccurred())
+		}
+	}
+
+	DescribeTable("Boot disk CMEK references",
+		tableFunc,
+		Entry("Enabled key", encryptionInput{
+			keyName: enabledKey,
+		}),
+		Entry("Disabled key", encryptionInput{
+			keyName:              disabledKey,
+			expectedErrSubstring: "is not enabled",
+		}),
+		Entry("Key that does not exist", encryptionInput{
+			keyName:              "projects/fooproject/locations/global/keyRings/my-ring/cryptoKeys/missing-key",
+			expectedErrSubstring: "unable to retrieve KMS key",
+		}),
+		Entry("Malformed key reference", encryptionInput{
+			keyName:              "my-ring/my-key",
+			expectedErrSubstring: "malformed KMS key reference",
+		}),
+	)
+
+	Context("When no disk specifies an encryption key", func() {
+		BeforeEach(func() {
+			providerSpec.Disks = []*machinev1.GCPDisk{
+				{
+					Boot:  true,
+					Image: "projects/fooproject/global/images/uefi-image",
+				},
+			}
+			err = util.ValidateDiskEncryption(computeService, providerSpec, util.WithKMSService(kmsService))
+		})
+
+		It("passes validation", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("When the boot image is itself CMEK-encrypted", func() {
+		Context("and the boot disk specifies no encryption key", func() {
+			BeforeEach(func() {
+				providerSpec.Disks = []*machinev1.GCPDisk{
+					{
+						Boot:  true,
+						Image: "projects/fooproject/global/images/cmek-encrypted-image",
+					},
+				}
+				err = util.ValidateDiskEncryption(computeService, providerSpec, util.WithKMSService(kmsService))
+			})
+
+			It("returns ErrBootDiskKeyMismatch", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(errors.Is(err, util.ErrBootDiskKeyMismatch)).To(BeTrue())
+				Expect(err.Error()).To(ContainSubstring("specifies no encryption key"))
+			})
+		})
+
+		Context("and the boot disk references a key in a different key ring", func() {
+			BeforeEach(func() {
+				providerSpec.Disks = []*machinev1.GCPDisk{
+					{
+						Boot:  true,
+						Image: "projects/fooproject/global/images/cmek-encrypted-image",
+						EncryptionKey: &machinev1.GCPEncryptionKeyReference{
+							KMSKeyName: otherRing,
+						},
+					},
+				}
+				err = util.ValidateDiskEncryption(computeService, providerSpec, util.WithKMSService(kmsService))
+			})
+
+			It("returns ErrBootDiskKeyMismatch", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(errors.Is(err, util.ErrBootDiskKeyMismatch)).To(BeTrue())
+				Expect(err.Error()).To(ContainSubstring("is not in the same key ring"))
+			})
+		})
+
+		Context("and the boot disk references a key in the same key ring as the image", func() {
+			BeforeEach(func() {
+				providerSpec.Disks = []*machinev1.GCPDisk{
+					{
+						Boot:  true,
+						Image: "projects/fooproject/global/images/cmek-encrypted-image",
+						EncryptionKey: &machinev1.GCPEncryptionKeyReference{
+							KMSKeyName: enabledKey,
+						},
+					},
+				}
+				err = util.ValidateDiskEncryption(computeService, providerSpec, util.WithKMSService(kmsService))
+			})
+
+			It("passes validation", func() {
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+	})
+})
@@ -0,0 +1,171 @@
+package util
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+
+	computeservice "github.com/openshift/machine-api-provider-gcp/pkg/cloud/gcp/actuators/services/compute"
+)
+
+// defaultImageCacheTTL is how long a resolved image (or a NotFound result)
+// is cached for when ImageMetadataCache is constructed with a ttl of 0.
+const defaultImageCacheTTL = 5 * time.Minute
+
+// imageCacheKey is the canonical (project, kind, name) tuple a resolved
+// image is cached under, as produced by NormalizeImageURL.
+type imageCacheKey struct {
+	project string
+	kind    ImageKind
+	name    string
+}
+
+type imageCacheEntry struct {
+	image     *compute.Image
+	err       error
+	expiresAt time.Time
+}
+
+// ImageMetadataCache caches resolved images by their canonical
+// (project, kind, name) tuple so that repeated lookups of the same image -
+// for example from many machines in a MachineSet scaling up together -
+// collapse into a single compute API call. Both successful lookups and
+// NotFound errors are cached for ttl, and concurrent lookups for the same
+// key are collapsed with singleflight so only one of them ever reaches the
+// compute API.
+type ImageMetadataCache struct {
+	ttl   time.Duration
+	group singleflight.Group
+
+	mu      sync.Mutex
+	entries map[imageCacheKey]imageCacheEntry
+}
+
+// NewImageMetadataCache returns an ImageMetadataCache that caches entries
+// for ttl. A ttl of 0 uses the default of 5 minutes.
+func NewImageMetadataCache(ttl time.Duration) *ImageMetadataCache {
+	if ttl <= 0 {
+		ttl = defaultImageCacheTTL
+	}
+
+	return &ImageMetadataCache{
+		ttl:     ttl,
+		entries: make(map[imageCacheKey]imageCacheEntry),
+	}
+}
+
+// getOrResolve returns the cached result for key if present and unexpired.
+// Otherwise it calls resolve, collapsing concurrent calls for the same key
+// into one. A successful result, or a genuine NotFound error, is cached for
+// ttl; any other error (rate limiting, a dropped connection, an IAM hiccup,
+// ...) is treated as transient and left uncached, so the next caller - for
+// example the next machine in the same scale-up - gets to retry instead of
+// inheriting a stale failure for the rest of the TTL.
+func (c *ImageMetadataCache) getOrResolve(key imageCacheKey, resolve func() (*compute.Image, error)) (*compute.Image, error) {
+	if entry, ok := c.lookup(key); ok {
+		return entry.image, entry.err
+	}
+
+	groupKey := fmt.Sprintf("%s/%d/%s", key.project, key.kind, key.name)
+
+	result, err, _ := c.group.Do(groupKey, func() (interface{}, error) {
+		if entry, ok := c.lookup(key); ok {
+			return entry.image, entry.err
+		}
+
+		image, resolveErr := resolve()
+
+		if resolveErr == nil || googleapi.IsNotFound(resolveErr) {
+			c.store(key, image, resolveErr)
+		}
+
+		return image, resolveErr
+	})
+
+	if result == nil {
+		return nil, err
+	}
+
+	return result.(*compute.Image), err
+}
+
+func (c *ImageMetadataCache) lookup(key imageCacheKey) (imageCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return imageCacheEntry{}, false
+	}
+
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return imageCacheEntry{}, false
+	}
+
+	return entry, true
+}
+
+func (c *ImageMetadataCache) store(key imageCacheKey, image *compute.Image, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = imageCacheEntry{image: image, err: err, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// ResolveImageOption customizes the behaviour of ResolveImage and
+// IsUEFICompatible.
+type ResolveImageOption func(*resolveImageOptions)
+
+type resolveImageOptions struct {
+	cache *ImageMetadataCache
+}
+
+// WithImageCache makes ResolveImage (and IsUEFICompatible) serve image
+// lookups through cache instead of calling the compute API directly every
+// time.
+func WithImageCache(cache *ImageMetadataCache) ResolveImageOption {
+	return func(o *resolveImageOptions) {
+		o.cache = cache
+	}
+}
+
+func resolveImageOptionsFrom(opts []ResolveImageOption) *resolveImageOptions {
+	o := &resolveImageOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// cachedImagesGet is a cache-aware wrapper around
+// computeService.ImagesGet. If cache is nil it calls through directly.
+func cachedImagesGet(computeService computeservice.GCPComputeService, cache *ImageMetadataCache, project, name string) (*compute.Image, error) {
+	if cache == nil {
+		return computeService.ImagesGet(project, name)
+	}
+
+	key := imageCacheKey{project: project, kind: ImageKindImage, name: name}
+	return cache.getOrResolve(key, func() (*compute.Image, error) {
+		return computeService.ImagesGet(project, name)
+	})
+}
+
+// cachedImagesGetFromFamily is a cache-aware wrapper around
+// computeService.ImagesGetFromFamily. If cache is nil it calls through
+// directly.
+func cachedImagesGetFromFamily(computeService computeservice.GCPComputeService, cache *ImageMetadataCache, project, name string) (*compute.Image, error) {
+	if cache == nil {
+		return computeService.ImagesGetFromFamily(project, name)
+	}
+
+	key := imageCacheKey{project: project, kind: ImageKindFamily, name: name}
+	return cache.getOrResolve(key, func() (*compute.Image, error) {
+		return computeService.ImagesGetFromFamily(project, name)
+	})
+}
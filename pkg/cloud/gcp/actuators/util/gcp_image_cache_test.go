@@ -0,0 +1,162 @@
+package util_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	computeservice "github.com/openshift/machine-api-provider-gcp/pkg/cloud/gcp/actuators/services/compute"
+	"github.com/openshift/machine-api-provider-gcp/pkg/cloud/gcp/actuators/util"
+)
+
+// countingComputeService wraps a GCPComputeService and counts calls to
+// ImagesGet, so tests can assert how many times the underlying API was
+// actually hit.
+type countingComputeService struct {
+	computeservice.GCPComputeService
+	imagesGetCalls int32
+}
+
+func (c *countingComputeService) ImagesGet(project, name string) (*compute.Image, error) {
+	atomic.AddInt32(&c.imagesGetCalls, 1)
+	return c.GCPComputeService.ImagesGet(project, name)
+}
+
+// scriptedComputeService wraps a GCPComputeService and returns the
+// configured errs, in order, for the first len(errs) calls to ImagesGet,
+// falling through to the wrapped service afterwards. Used to simulate
+// transient API failures and persistent NotFound errors.
+type scriptedComputeService struct {
+	computeservice.GCPComputeService
+	errs  []error
+	calls int32
+}
+
+func (s *scriptedComputeService) ImagesGet(project, name string) (*compute.Image, error) {
+	idx := int(atomic.AddInt32(&s.calls, 1)) - 1
+	if idx < len(s.errs) {
+		return nil, s.errs[idx]
+	}
+
+	return s.GCPComputeService.ImagesGet(project, name)
+}
+
+var _ = Describe("ImageMetadataCache", func() {
+	var (
+		inner        computeservice.GCPComputeService
+		counting     *countingComputeService
+		providerSpec *machinev1.GCPMachineProviderSpec
+	)
+
+	BeforeEach(func() {
+		_, inner = computeservice.NewComputeServiceMock()
+		counting = &countingComputeService{GCPComputeService: inner}
+		providerSpec = &machinev1.GCPMachineProviderSpec{
+			ProjectID: "fooproject",
+		}
+	})
+
+	It("collapses concurrent lookups of the same image into one API call", func() {
+		cache := util.NewImageMetadataCache(time.Minute)
+
+		const concurrency = 10
+
+		var wg sync.WaitGroup
+		wg.Add(concurrency)
+
+		for i := 0; i < concurrency; i++ {
+			go func() {
+				defer wg.Done()
+				defer GinkgoRecover()
+
+				_, err := util.ResolveImage(counting, providerSpec, "projects/fooproject/global/images/uefi-image", util.WithImageCache(cache))
+				Expect(err).ToNot(HaveOccurred())
+			}()
+		}
+
+		wg.Wait()
+
+		Expect(atomic.LoadInt32(&counting.imagesGetCalls)).To(Equal(int32(1)))
+	})
+
+	It("refreshes the entry once the TTL has expired", func() {
+		cache := util.NewImageMetadataCache(10 * time.Millisecond)
+
+		_, err := util.ResolveImage(counting, providerSpec, "projects/fooproject/global/images/uefi-image", util.WithImageCache(cache))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(atomic.LoadInt32(&counting.imagesGetCalls)).To(Equal(int32(1)))
+
+		_, err = util.ResolveImage(counting, providerSpec, "projects/fooproject/global/images/uefi-image", util.WithImageCache(cache))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(atomic.LoadInt32(&counting.imagesGetCalls)).To(Equal(int32(1)))
+
+		time.Sleep(20 * time.Millisecond)
+
+		_, err = util.ResolveImage(counting, providerSpec, "projects/fooproject/global/images/uefi-image", util.WithImageCache(cache))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(atomic.LoadInt32(&counting.imagesGetCalls)).To(Equal(int32(2)))
+	})
+
+	It("does not cache a transient error, so the next caller retries", func() {
+		cache := util.NewImageMetadataCache(time.Minute)
+		scripted := &scriptedComputeService{
+			GCPComputeService: inner,
+			errs:              []error{errors.New("rate limit exceeded")},
+		}
+
+		_, err := util.ResolveImage(scripted, providerSpec, "projects/fooproject/global/images/uefi-image", util.WithImageCache(cache))
+		Expect(err).To(HaveOccurred())
+
+		_, err = util.ResolveImage(scripted, providerSpec, "projects/fooproject/global/images/uefi-image", util.WithImageCache(cache))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(atomic.LoadInt32(&scripted.calls)).To(Equal(int32(2)))
+	})
+
+	It("caches a genuine NotFound error for the TTL", func() {
+		cache := util.NewImageMetadataCache(time.Minute)
+		notFound := &googleapi.Error{Code: 404, Message: "not found"}
+		scripted := &scriptedComputeService{
+			GCPComputeService: inner,
+			errs:              []error{notFound, notFound, notFound},
+		}
+
+		_, err := util.ResolveImage(scripted, providerSpec, "projects/fooproject/global/images/uefi-image", util.WithImageCache(cache))
+		Expect(err).To(HaveOccurred())
+
+		_, err = util.ResolveImage(scripted, providerSpec, "projects/fooproject/global/images/uefi-image", util.WithImageCache(cache))
+		Expect(err).To(HaveOccurred())
+
+		Expect(atomic.LoadInt32(&scripted.calls)).To(Equal(int32(1)))
+	})
+
+	It("shares one cache across IsUEFICompatible, IsArchitectureCompatible and ValidateDiskEncryption", func() {
+		cache := util.NewImageMetadataCache(time.Minute)
+		providerSpec.MachineType = "n2-standard-4"
+		providerSpec.Disks = []*machinev1.GCPDisk{
+			{
+				Boot:  true,
+				Image: "projects/fooproject/global/images/uefi-image",
+			},
+		}
+
+		_, err := util.IsUEFICompatible(counting, providerSpec, util.WithImageCache(cache))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = util.IsArchitectureCompatible(counting, providerSpec, util.WithImageCache(cache))
+		Expect(err).ToNot(HaveOccurred())
+
+		err = util.ValidateDiskEncryption(counting, providerSpec, util.WithResolveImageOptions(util.WithImageCache(cache)))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(atomic.LoadInt32(&counting.imagesGetCalls)).To(Equal(int32(1)))
+	})
+})
@@ -0,0 +1,84 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+)
+
+// ImageKind distinguishes a reference to a single image from a reference to
+// an image family, since the two are resolved via different compute API
+// calls.
+type ImageKind int
+
+const (
+	// ImageKindImage refers to a single, specific compute image.
+	ImageKindImage ImageKind = iota
+	// ImageKindFamily refers to an image family, which always resolves to
+	// that family's newest non-deprecated image.
+	ImageKindFamily
+)
+
+// NormalizeImageURL parses the handful of image reference forms GCP accepts
+// in an instance template into a canonical (project, kind, name) tuple that
+// callers can resolve without caring which form was originally used. It
+// accepts:
+//
+//   - a full self-link, in any API version:
+//     https://www.googleapis.com/compute/{v1,beta,alpha}/projects/{p}/global/images/{i}
+//     (and the equivalent .../global/images/family/{f} form)
+//   - "projects/{p}/global/images/{i}" (and .../family/{f})
+//   - "global/images/{i}" (and .../family/{f}), which resolve against
+//     providerSpec.ProjectID
+//   - a bare image or family name, which also resolves against
+//     providerSpec.ProjectID
+func NormalizeImageURL(providerSpec *machinev1.GCPMachineProviderSpec, diskImage string) (project string, kind ImageKind, name string, err error) {
+	path := diskImage
+
+	if strings.HasPrefix(diskImage, "https://") {
+		const marker = "/projects/"
+		idx := strings.Index(diskImage, marker)
+		if idx == -1 {
+			return "", 0, "", fmt.Errorf("image reference %q does not contain expected 'projects/' segment", diskImage)
+		}
+		path = "projects/" + diskImage[idx+len(marker):]
+	}
+
+	if strings.HasPrefix(path, "projects/") {
+		segments := strings.Split(strings.TrimPrefix(path, "projects/"), "/")
+
+		switch {
+		case len(segments) == 4 && segments[1] == "global" && segments[2] == "images":
+			return segments[0], ImageKindImage, segments[3], nil
+		case len(segments) == 5 && segments[1] == "global" && segments[2] == "images" && segments[3] == "family":
+			return segments[0], ImageKindFamily, segments[4], nil
+		case len(segments) == 3 && segments[1] == "global" && segments[2] == "images":
+			return "", 0, "", fmt.Errorf("unexpected image path format %q: missing image name", diskImage)
+		default:
+			return "", 0, "", fmt.Errorf("unrecognized image path format %q", diskImage)
+		}
+	}
+
+	if strings.HasPrefix(path, "global/images/family/") {
+		name := strings.TrimPrefix(path, "global/images/family/")
+		if name == "" || strings.Contains(name, "/") {
+			return "", 0, "", fmt.Errorf("unrecognized image path format %q", diskImage)
+		}
+		return providerSpec.ProjectID, ImageKindFamily, name, nil
+	}
+
+	if strings.HasPrefix(path, "global/images/") {
+		name := strings.TrimPrefix(path, "global/images/")
+		if name == "" || strings.Contains(name, "/") {
+			return "", 0, "", fmt.Errorf("unrecognized image path format %q", diskImage)
+		}
+		return providerSpec.ProjectID, ImageKindImage, name, nil
+	}
+
+	if strings.Contains(path, "/") {
+		return "", 0, "", fmt.Errorf("unrecognized image path format %q", diskImage)
+	}
+
+	return providerSpec.ProjectID, ImageKindImage, path, nil
+}
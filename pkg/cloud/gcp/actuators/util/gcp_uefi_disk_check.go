@@ -0,0 +1,225 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/compute/v1"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	computeservice "github.com/openshift/machine-api-provider-gcp/pkg/cloud/gcp/actuators/services/compute"
+)
+
+// uefiCompatibleFeature is the GuestOsFeatures.Type value GCP sets on images
+// that support booting with UEFI firmware.
+const uefiCompatibleFeature = "UEFI_COMPATIBLE"
+
+// wellKnownPublicImageProjects maps the short prefixes users are allowed to
+// use in GCPDisk.Image to the GCP project that actually hosts the public
+// image family. This lets a provider spec say "debian-11" instead of the
+// fully qualified "projects/debian-cloud/global/images/family/debian-11".
+var wellKnownPublicImageProjects = []struct {
+	prefix  string
+	project string
+}{
+	{"debian-", "debian-cloud"},
+	{"ubuntu-", "ubuntu-os-cloud"},
+	{"centos-", "centos-cloud"},
+	{"rhel-", "rhel-cloud"},
+	{"sles-", "suse-cloud"},
+	{"sql-", "windows-sql-cloud"},
+	{"windows-", "windows-cloud"},
+	{"cos-", "cos-cloud"},
+	{"fedora-coreos-", "fedora-coreos-cloud"},
+}
+
+// publicImageProjectFor returns the public project that hosts the well-known
+// image or family referred to by name, and whether one was found. Names like
+// "ubuntu-2204-lts" and "sql-2019-standard-windows-2022-dc" already match the
+// generic "ubuntu-"/"sql-" prefixes above, so no dedicated pattern is needed
+// for them.
+func publicImageProjectFor(name string) (string, bool) {
+	for _, candidate := range wellKnownPublicImageProjects {
+		if strings.HasPrefix(name, candidate.prefix) {
+			return candidate.project, true
+		}
+	}
+
+	return "", false
+}
+
+// ImageResolutionTier identifies one of the fallback tiers ResolveImage
+// tries, in order, when resolving a bare image name.
+type ImageResolutionTier string
+
+const (
+	TierExactImageInProject        ImageResolutionTier = "exact image in project"
+	TierImageFamilyInProject       ImageResolutionTier = "image family in project"
+	TierExactImageInPublicProject  ImageResolutionTier = "exact image in public project"
+	TierImageFamilyInPublicProject ImageResolutionTier = "image family in public project"
+)
+
+// ImageResolutionAttempt records one fallback tier ResolveImage tried while
+// resolving a bare image name, and why it failed.
+type ImageResolutionAttempt struct {
+	Tier    ImageResolutionTier
+	Project string
+	Err     error
+}
+
+// ImageResolutionError is returned by ResolveImage when a bare image name
+// could not be resolved in any of its fallback tiers. Callers can inspect
+// Attempts to distinguish, for example, "every tier 404'd" from "the public
+// project lookup was denied by IAM".
+type ImageResolutionError struct {
+	Image    string
+	Attempts []ImageResolutionAttempt
+}
+
+func (e *ImageResolutionError) Error() string {
+	parts := make([]string, 0, len(e.Attempts))
+	for _, attempt := range e.Attempts {
+		parts = append(parts, fmt.Sprintf("%s: unable to retrieve image from project %q: %v", attempt.Tier, attempt.Project, attempt.Err))
+	}
+
+	return fmt.Sprintf("unable to resolve image %q, tried: %s", e.Image, strings.Join(parts, "; "))
+}
+
+// Unwrap exposes each tier's underlying error, so callers can use
+// errors.Is/errors.As against them. A match is reported if any one tier's
+// error matches; to check every tier, inspect Attempts.
+func (e *ImageResolutionError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Attempts))
+	for _, attempt := range e.Attempts {
+		errs = append(errs, attempt.Err)
+	}
+
+	return errs
+}
+
+// ResolveImage resolves diskImage to a *compute.Image. Explicit references
+// (self-links, or "projects/{p}/..." / "global/images[/family]/{name}"
+// paths, as normalized by NormalizeImageURL) are looked up directly. A bare
+// name is tried, in order, as:
+//
+//  1. an exact image named diskImage in providerSpec.ProjectID
+//  2. an image family named diskImage in providerSpec.ProjectID
+//  3. if diskImage matches a well-known public image prefix, an exact image
+//     of that name in the mapped public project
+//  4. a family named diskImage in the mapped public project
+//
+// If none of the tiers resolve the image, the returned error is an
+// *ImageResolutionError identifying which tiers were tried and why each one
+// failed.
+//
+// By default every call hits the compute API directly. Pass WithImageCache
+// to serve lookups through an ImageMetadataCache instead.
+func ResolveImage(computeService computeservice.GCPComputeService, providerSpec *machinev1.GCPMachineProviderSpec, diskImage string, opts ...ResolveImageOption) (*compute.Image, error) {
+	options := resolveImageOptionsFrom(opts)
+
+	project, kind, name, err := NormalizeImageURL(providerSpec, diskImage)
+	if err != nil {
+		return nil, err
+	}
+
+	// A reference that named an explicit path (as opposed to a bare name) is
+	// only ever looked up in that one project/kind: there is nothing to fall
+	// back to.
+	if strings.Contains(diskImage, "/") {
+		return getImage(computeService, options.cache, project, kind, name)
+	}
+
+	var attempts []ImageResolutionAttempt
+
+	if image, err := cachedImagesGet(computeService, options.cache, project, name); err == nil {
+		return image, nil
+	} else {
+		attempts = append(attempts, ImageResolutionAttempt{Tier: TierExactImageInProject, Project: project, Err: err})
+	}
+
+	if image, err := cachedImagesGetFromFamily(computeService, options.cache, project, name); err == nil {
+		return image, nil
+	} else {
+		attempts = append(attempts, ImageResolutionAttempt{Tier: TierImageFamilyInProject, Project: project, Err: err})
+	}
+
+	if publicProject, ok := publicImageProjectFor(name); ok {
+		if image, err := cachedImagesGet(computeService, options.cache, publicProject, name); err == nil {
+			return image, nil
+		} else {
+			attempts = append(attempts, ImageResolutionAttempt{Tier: TierExactImageInPublicProject, Project: publicProject, Err: err})
+		}
+
+		if image, err := cachedImagesGetFromFamily(computeService, options.cache, publicProject, name); err == nil {
+			return image, nil
+		} else {
+			attempts = append(attempts, ImageResolutionAttempt{Tier: TierImageFamilyInPublicProject, Project: publicProject, Err: err})
+		}
+	}
+
+	return nil, &ImageResolutionError{Image: diskImage, Attempts: attempts}
+}
+
+// getImage looks up a single, already-disambiguated (project, kind, name)
+// tuple, without trying any further fallback tiers.
+func getImage(computeService computeservice.GCPComputeService, cache *ImageMetadataCache, project string, kind ImageKind, name string) (*compute.Image, error) {
+	switch kind {
+	case ImageKindFamily:
+		image, err := cachedImagesGetFromFamily(computeService, cache, project, name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to retrieve image family %q in project %q: %v", name, project, err)
+		}
+		return image, nil
+	default:
+		image, err := cachedImagesGet(computeService, cache, project, name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to retrieve image %q in project %q: %v", name, project, err)
+		}
+		return image, nil
+	}
+}
+
+// IsUEFICompatible reports whether the boot disk's image in providerSpec
+// supports UEFI. It resolves the image (including short public image names
+// and the various path/self-link forms handled by ResolveImage), inspects
+// its GuestOsFeatures, and also validates that the image's architecture is
+// compatible with providerSpec.MachineType. Pass WithImageCache to resolve
+// the image through an ImageMetadataCache instead of the compute API
+// directly.
+func IsUEFICompatible(computeService computeservice.GCPComputeService, providerSpec *machinev1.GCPMachineProviderSpec, opts ...ResolveImageOption) (bool, error) {
+	bootDisk, err := findBootDisk(providerSpec)
+	if err != nil {
+		return false, err
+	}
+
+	image, err := ResolveImage(computeService, providerSpec, bootDisk.Image, opts...)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := checkImageArchitecture(image, providerSpec); err != nil {
+		return false, err
+	}
+
+	return imageHasUEFISupport(image), nil
+}
+
+func findBootDisk(providerSpec *machinev1.GCPMachineProviderSpec) (*machinev1.GCPDisk, error) {
+	for _, disk := range providerSpec.Disks {
+		if disk.Boot {
+			return disk, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no boot disk found in provider spec")
+}
+
+func imageHasUEFISupport(image *compute.Image) bool {
+	for _, feature := range image.GuestOsFeatures {
+		if feature.Type == uefiCompatibleFeature {
+			return true
+		}
+	}
+
+	return false
+}
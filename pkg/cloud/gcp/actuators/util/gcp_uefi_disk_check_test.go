@@ -1,6 +1,8 @@
 package util_test
 
 import (
+	"errors"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -107,6 +109,59 @@ var _ = Describe("IsUEFICompatible", func() {
 		}),
 	)
 
+	DescribeTable("Well-known public image short names",
+		tableFunc,
+		Entry("Debian family short name", standardImageInput{
+			boot:       true,
+			image:      "debian-11",
+			projectID:  "my-project",
+			compatible: true,
+		}),
+		Entry("Ubuntu LTS family short name", standardImageInput{
+			boot:       true,
+			image:      "ubuntu-2204-lts",
+			projectID:  "my-project",
+			compatible: true,
+		}),
+		Entry("CentOS Stream family short name", standardImageInput{
+			boot:       true,
+			image:      "centos-stream-9",
+			projectID:  "my-project",
+			compatible: true,
+		}),
+		Entry("RHEL family short name", standardImageInput{
+			boot:       true,
+			image:      "rhel-9",
+			projectID:  "my-project",
+			compatible: true,
+		}),
+		Entry("SLES family short name", standardImageInput{
+			boot:       true,
+			image:      "sles-15",
+			projectID:  "my-project",
+			compatible: true,
+		}),
+		Entry("Windows Server family short name", standardImageInput{
+			boot:       true,
+			image:      "windows-2022",
+			projectID:  "my-project",
+			compatible: true,
+		}),
+		Entry("SQL Server on Windows family short name", standardImageInput{
+			boot:       true,
+			image:      "sql-2019-standard-windows-2022-dc",
+			projectID:  "my-project",
+			compatible: true,
+		}),
+		Entry("Unresolvable short name", standardImageInput{
+			boot:                 true,
+			image:                "not-a-known-image",
+			projectID:            "errImageNotFound",
+			expectedErrSubstring: "unable to resolve image",
+			compatible:           false,
+		}),
+	)
+
 	DescribeTable("Family image references in format projects/{project}/global/images/family/{imageFamily}",
 		tableFunc,
 		Entry("UEFI compatible image family", standardImageInput{
@@ -160,6 +215,34 @@ var _ = Describe("IsUEFICompatible", func() {
 			expectedErrSubstring: "unrecognized image path format",
 			compatible:           false,
 		}),
+		Entry("Beta FQDN image", standardImageInput{
+			boot:       true,
+			image:      "https://www.googleapis.com/compute/beta/projects/fooproject/global/images/uefi-image",
+			compatible: true,
+		}),
+		Entry("Beta FQDN image family", standardImageInput{
+			boot:       true,
+			image:      "https://www.googleapis.com/compute/beta/projects/fooproject/global/images/family/uefi-image-family",
+			zone:       "us-central1-a",
+			compatible: true,
+		}),
+	)
+
+	DescribeTable("Partial image path references",
+		tableFunc,
+		Entry("global/images/{image} uses providerSpec.ProjectID", standardImageInput{
+			boot:       true,
+			image:      "global/images/uefi-image",
+			projectID:  "fooproject",
+			compatible: true,
+		}),
+		Entry("global/images/family/{family} uses providerSpec.ProjectID", standardImageInput{
+			boot:       true,
+			image:      "global/images/family/uefi-image-family",
+			projectID:  "fooproject",
+			zone:       "us-central1-a",
+			compatible: true,
+		}),
 	)
 
 	Context("Corner cases", func() {
@@ -209,4 +292,32 @@ var _ = Describe("IsUEFICompatible", func() {
 
 	})
 
+	Context("When a bare image name cannot be resolved in any tier", func() {
+		BeforeEach(func() {
+			providerSpec.ProjectID = "errImageNotFound"
+			providerSpec.Disks = []*machinev1.GCPDisk{
+				{
+					Boot:  true,
+					Image: "not-a-known-image",
+				},
+			}
+			compatible, err = util.IsUEFICompatible(computeService, providerSpec)
+		})
+
+		It("returns a structured ImageResolutionError with one attempt per tier tried", func() {
+			Expect(err).To(HaveOccurred())
+
+			var resolutionErr *util.ImageResolutionError
+			Expect(errors.As(err, &resolutionErr)).To(BeTrue())
+
+			Expect(resolutionErr.Image).To(Equal("not-a-known-image"))
+			Expect(resolutionErr.Attempts).To(HaveLen(2))
+			Expect(resolutionErr.Attempts[0].Tier).To(Equal(util.TierExactImageInProject))
+			Expect(resolutionErr.Attempts[1].Tier).To(Equal(util.TierImageFamilyInProject))
+			for _, attempt := range resolutionErr.Attempts {
+				Expect(attempt.Err).To(HaveOccurred())
+			}
+		})
+	})
+
 })